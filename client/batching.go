@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// DefaultMaxBatchSize bounds how many operations NewBatchingClient will
+// coalesce into a single HTTP request when no explicit limit is given.
+const DefaultMaxBatchSize = 10
+
+// BatchingClient wraps a Client and coalesces Post calls that arrive within
+// Window of each other into a single JSON-array request, the de-facto
+// GraphQL batching format supported by Apollo Server, GraphQL Yoga and
+// gqlgen. Responses are demultiplexed back to each caller in call order.
+type BatchingClient struct {
+	*Client
+
+	// Window is how long a batch stays open for more requests to join
+	// before it is flushed.
+	Window time.Duration
+	// MaxBatchSize is the largest number of requests sent in one HTTP
+	// call; the batch is flushed early once it is reached.
+	MaxBatchSize int
+
+	mu      sync.Mutex
+	pending []*batchedRequest
+	timer   *time.Timer
+}
+
+type batchedRequest struct {
+	ctx      context.Context
+	req      *Request
+	respData interface{}
+	done     chan error
+}
+
+// NewBatchingClient wraps client so that Post calls are batched together.
+// A maxBatchSize <= 0 uses DefaultMaxBatchSize.
+func NewBatchingClient(client *Client, window time.Duration, maxBatchSize int) *BatchingClient {
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+
+	return &BatchingClient{
+		Client:       client,
+		Window:       window,
+		MaxBatchSize: maxBatchSize,
+	}
+}
+
+// Post enqueues the request to be sent as part of the next batch and blocks
+// until its response arrives, the request's context is cancelled, or the
+// batch's HTTP call fails outright.
+//
+// BatchingClient sends the JSON-array batch itself, bypassing Client.Post
+// entirely, so it cannot honor PersistedQueryCache or Middlewares - there is
+// no single request for APQ's hash/extensions to attach to, and no
+// RoundTripper for a middleware to wrap. Both are rejected explicitly
+// rather than silently ignored.
+func (c *BatchingClient) Post(ctx context.Context, query string, respData interface{}, vars map[string]interface{}, _ ...HTTPRequestOption) error {
+	if c.PersistedQueryCache != nil {
+		return xerrors.Errorf("client: BatchingClient does not support Automatic Persisted Queries")
+	}
+	if len(c.Middlewares) > 0 {
+		return xerrors.Errorf("client: BatchingClient does not support the middleware chain; install middlewares on the underlying http.Client's Transport instead")
+	}
+
+	br := &batchedRequest{
+		ctx: ctx,
+		req: &Request{
+			Query:     query,
+			Variables: removeNils(vars),
+		},
+		respData: respData,
+		done:     make(chan error, 1),
+	}
+
+	c.enqueue(br)
+
+	select {
+	case err := <-br.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *BatchingClient) enqueue(br *batchedRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = append(c.pending, br)
+
+	if len(c.pending) >= c.MaxBatchSize {
+		if c.timer != nil {
+			c.timer.Stop()
+			c.timer = nil
+		}
+		batch := c.pending
+		c.pending = nil
+		go c.flush(batch)
+		return
+	}
+
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.Window, c.flushPending)
+	}
+}
+
+func (c *BatchingClient) flushPending() {
+	c.mu.Lock()
+	batch := c.pending
+	c.pending = nil
+	c.timer = nil
+	c.mu.Unlock()
+
+	c.flush(batch)
+}
+
+func (c *BatchingClient) flush(batch []*batchedRequest) {
+	if len(batch) == 0 {
+		return
+	}
+
+	live := batch[:0]
+	for _, br := range batch {
+		if err := br.ctx.Err(); err != nil {
+			br.done <- err
+			continue
+		}
+		live = append(live, br)
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	requests := make([]*Request, len(live))
+	for i, br := range live {
+		requests[i] = br.req
+	}
+
+	// The batch is one shared HTTP call, so it can only carry one
+	// context; use the first still-live member's. Its cancellation aborts
+	// the network call for the whole batch, same as any other caller
+	// sharing a connection would expect.
+	bodies, httpCode, err := c.doBatch(live[0].ctx, requests)
+	if err != nil {
+		for _, br := range live {
+			br.done <- err
+		}
+		return
+	}
+
+	for i, br := range live {
+		if i >= len(bodies) {
+			br.done <- xerrors.Errorf("batch response missing entry %d of %d", i, len(bodies))
+			continue
+		}
+
+		resp, err := decodeResponse(bodies[i], httpCode)
+		if err != nil {
+			if httpErr, ok := err.(*HTTPError); ok {
+				br.done <- &ErrorResponse{NetworkError: httpErr}
+			} else {
+				br.done <- err
+			}
+			continue
+		}
+		br.done <- finishResponse(resp, br.respData)
+	}
+}
+
+func (c *BatchingClient) doBatch(ctx context.Context, requests []*Request) ([]json.RawMessage, int, error) {
+	requestBody, err := json.Marshal(requests)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("encode batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, 0, xerrors.Errorf("create batch request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Accept", "application/json; charset=utf-8")
+	for _, httpRequestOption := range c.HTTPRequestOptions {
+		httpRequestOption(req)
+	}
+
+	resp, err := c.Client.Client.Do(req)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("batch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("failed to read batch response body: %w", err)
+	}
+
+	var bodies []json.RawMessage
+	if err := json.Unmarshal(body, &bodies); err != nil {
+		return nil, 0, xerrors.Errorf("failed to decode batch response %s: %w", string(body), err)
+	}
+
+	return bodies, resp.StatusCode, nil
+}
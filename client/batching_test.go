@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBatchingClient_UsesCallerContextForHTTPCall(t *testing.T) {
+	block := make(chan struct{})
+	serverSawCancel := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reading the body to EOF lets the server notice the client
+		// dropping the connection and cancel r.Context().
+		_, _ = io.ReadAll(r.Body)
+		select {
+		case <-r.Context().Done():
+			close(serverSawCancel)
+		case <-block:
+		}
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	bc := NewBatchingClient(NewClient(srv.Client(), srv.URL), time.Hour, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- bc.Post(ctx, "{ping}", &struct{}{}, nil)
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected an error wrapping context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Post did not return after its context was cancelled")
+	}
+
+	select {
+	case <-serverSawCancel:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the server's request was never cancelled; the batched HTTP call isn't using the caller's context")
+	}
+}
@@ -1,7 +1,6 @@
 package client
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -21,13 +20,22 @@ type Client struct {
 	Client             *http.Client
 	BaseURL            string
 	HTTPRequestOptions []HTTPRequestOption
+
+	// PersistedQueryCache, when set via WithPersistedQueries, enables
+	// Automatic Persisted Queries on every call to Post.
+	PersistedQueryCache PersistedQueryCache
+
+	// Middlewares, when installed via Use, wrap the GraphQL-layer
+	// transport used by Post.
+	Middlewares []Middleware
 }
 
 // Request represents an outgoing GraphQL request
 type Request struct {
-	Query         string                 `json:"query"`
+	Query         string                 `json:"query,omitempty"`
 	Variables     map[string]interface{} `json:"variables,omitempty"`
 	OperationName string                 `json:"operationName,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
 }
 
 // NewClient creates a new http client wrapper
@@ -55,30 +63,32 @@ func removeNils(initialMap map[string]interface{}) map[string]interface{} {
 	return withoutNils
 }
 
-func (c *Client) newRequest(ctx context.Context, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) (*http.Request, error) {
-	r := &Request{
-		Query:         query,
-		Variables:     removeNils(vars),
-		OperationName: "",
+// applyHTTPRequestOptions runs the client-wide options followed by any
+// options given to this specific call, so per-call options can override
+// client defaults.
+func (c *Client) applyHTTPRequestOptions(req *http.Request, httpRequestOptions []HTTPRequestOption) {
+	for _, httpRequestOption := range c.HTTPRequestOptions {
+		httpRequestOption(req)
 	}
-	requestBody, err := json.Marshal(r)
-	if err != nil {
-		return nil, xerrors.Errorf("encode: %w", err)
+	for _, httpRequestOption := range httpRequestOptions {
+		httpRequestOption(req)
 	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, bytes.NewBuffer(requestBody))
+// doHTTP performs req and returns its raw body and status code.
+func (c *Client) doHTTP(req *http.Request) ([]byte, int, error) {
+	resp, err := c.Client.Do(req)
 	if err != nil {
-		return nil, xerrors.Errorf("create request struct failed: %w", err)
+		return nil, 0, xerrors.Errorf("request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	for _, httpRequestOption := range c.HTTPRequestOptions {
-		httpRequestOption(req)
-	}
-	for _, httpRequestOption := range httpRequestOptions {
-		httpRequestOption(req)
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("failed to read response body: %w", err)
 	}
 
-	return req, nil
+	return body, resp.StatusCode, nil
 }
 
 // GqlErrorList is the struct of a standard graphql error response
@@ -96,6 +106,10 @@ type HTTPError struct {
 	Message string `json:"message"`
 }
 
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
 // ErrorResponse represent an handled error
 type ErrorResponse struct {
 	// populated when http status code is not OK
@@ -120,78 +134,61 @@ func (er *ErrorResponse) Error() string {
 
 // Post sends a http POST request to the graphql endpoint with the given query then unpacks
 // the response into the given object.
+//
+// Every combination of features - file uploads, Automatic Persisted
+// Queries and the middleware chain - is routed through the same
+// RoundTripper: uploads pick a multipart terminal instead of the plain
+// JSON one, and that terminal is wrapped by the installed middlewares the
+// same way the JSON terminal is. The one combination that genuinely can't
+// compose is uploads with APQ (multipart requests always carry the full
+// query text, so there is nothing to persist), which is rejected outright
+// rather than silently sending one and dropping the other.
 func (c *Client) Post(ctx context.Context, query string, respData interface{}, vars map[string]interface{}, httpRequestOptions ...HTTPRequestOption) error {
-	req, err := c.newRequest(ctx, query, vars, httpRequestOptions)
-	if err != nil {
-		return xerrors.Errorf("don't create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	req.Header.Set("Accept", "application/json; charset=utf-8")
+	cleanedVars, uploads := collectUploads(removeNils(vars))
 
-	resp, err := c.Client.Do(req)
-	if err != nil {
-		return xerrors.Errorf("request failed: %w", err)
+	if len(uploads) > 0 && c.PersistedQueryCache != nil {
+		return xerrors.Errorf("client: Automatic Persisted Queries cannot be combined with file uploads")
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return xerrors.Errorf("failed to read response body: %w", err)
+	rt := c.chain(c.terminalRoundTripper(uploads, httpRequestOptions))
+
+	if c.PersistedQueryCache != nil {
+		return c.postPersisted(ctx, rt, query, cleanedVars, respData)
 	}
 
-	return parseResponse(body, resp.StatusCode, respData)
+	return c.send(ctx, rt, &Request{Query: query, Variables: cleanedVars}, respData)
 }
 
-func parseResponse(body []byte, httpCode int, result interface{}) error {
-	errResponse := &ErrorResponse{}
-	isKOCode := httpCode < 200 || 299 < httpCode
-	if isKOCode {
-		errResponse.NetworkError = &HTTPError{
-			Code:    httpCode,
-			Message: fmt.Sprintf("Response body %s", string(body)),
-		}
-	}
-
-	// some servers return a graphql error with a non OK http code, try anyway to parse the body
-	if err := unmarshal(body, result); err != nil {
-		if gqlErr, ok := err.(*GqlErrorList); ok {
-			errResponse.GqlErrors = &gqlErr.Errors
-		} else if !isKOCode { // if is KO code there is already the http error, this error should not be returned
-			return err
+// send runs req through rt and unpacks the result into respData.
+func (c *Client) send(ctx context.Context, rt RoundTripper, req *Request, respData interface{}) error {
+	resp, err := rt.RoundTrip(ctx, req)
+	if err != nil {
+		if httpErr, ok := err.(*HTTPError); ok {
+			return &ErrorResponse{NetworkError: httpErr}
 		}
+		return xerrors.Errorf("request failed: %w", err)
 	}
 
-	if errResponse.HasErrors() {
-		return errResponse
-	}
-
-	return nil
-}
-
-// response is a GraphQL layer response from a handler.
-type response struct {
-	Data   json.RawMessage `json:"data"`
-	Errors json.RawMessage `json:"errors"`
+	return finishResponse(resp, respData)
 }
 
-func unmarshal(data []byte, res interface{}) error {
-	resp := response{}
-	if err := json.Unmarshal(data, &resp); err != nil {
-		return xerrors.Errorf("failed to decode data %s: %w", string(data), err)
+// finishResponse turns a decoded GraphQL-layer response into the
+// respData/error pair Post returns.
+func finishResponse(resp *Response, respData interface{}) error {
+	errResponse := &ErrorResponse{}
+	if resp.networkError != nil {
+		errResponse.NetworkError = resp.networkError
+	}
+	if len(resp.Errors) > 0 {
+		errResponse.GqlErrors = &resp.Errors
 	}
 
-	if resp.Errors != nil && len(resp.Errors) > 0 {
-		// try to parse standard graphql error
-		errors := &GqlErrorList{}
-		if e := json.Unmarshal(data, errors); e != nil {
-			return xerrors.Errorf("faild to parse graphql errors. Response content %s - %w ", string(data), e)
-		}
-
-		return errors
+	if err := graphqljson.UnmarshalData(resp.Data, respData); err != nil && errResponse.GqlErrors == nil {
+		return xerrors.Errorf("failed to decode data into response: %w", err)
 	}
 
-	if err := graphqljson.UnmarshalData(resp.Data, res); err != nil {
-		return xerrors.Errorf("failed to decode data into response %s: %w", string(data), err)
+	if errResponse.HasErrors() {
+		return errResponse
 	}
 
 	return nil
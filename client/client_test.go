@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPost_NonOKStatusWithGraphQLErrors_SetsNetworkErrorAndGqlErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":[{"message":"bad input"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), srv.URL)
+
+	err := c.Post(context.Background(), "query", &struct{}{}, nil)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+
+	errResp, ok := err.(*ErrorResponse)
+	if !ok {
+		t.Fatalf("err = %T, want *ErrorResponse", err)
+	}
+	if errResp.NetworkError == nil {
+		t.Fatal("NetworkError is nil, want the HTTP 400 preserved alongside GqlErrors")
+	}
+	if errResp.NetworkError.Code != http.StatusBadRequest {
+		t.Fatalf("NetworkError.Code = %d, want %d", errResp.NetworkError.Code, http.StatusBadRequest)
+	}
+	if errResp.GqlErrors == nil || len(*errResp.GqlErrors) != 1 {
+		t.Fatalf("GqlErrors = %v, want one error", errResp.GqlErrors)
+	}
+}
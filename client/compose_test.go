@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// countingMiddleware counts how many times the chain it's installed in
+// actually reaches the HTTP call, regardless of which terminal RoundTripper
+// is selected.
+func countingMiddleware(calls *int) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			*calls++
+			return next.RoundTrip(ctx, req)
+		})
+	}
+}
+
+func TestPost_Upload_GoesThroughMiddlewareChain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer srv.Close()
+
+	var calls int
+	c := NewClient(srv.Client(), srv.URL).Use(countingMiddleware(&calls))
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	err := c.Post(context.Background(), "mutation", &resp, map[string]interface{}{
+		"file": Upload{File: strings.NewReader("contents"), Filename: "a.txt"},
+	})
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("middleware chain ran %d times, want 1 - uploads should wrap through Middlewares same as a plain request", calls)
+	}
+	if !resp.OK {
+		t.Fatalf("resp.OK = false, want true")
+	}
+}
+
+func TestPost_UploadWithPersistedQueries_Rejected(t *testing.T) {
+	c := NewClient(http.DefaultClient, "http://example.invalid").WithPersistedQueries(nil)
+
+	err := c.Post(context.Background(), "mutation", &struct{}{}, map[string]interface{}{
+		"file": Upload{File: strings.NewReader("contents"), Filename: "a.txt"},
+	})
+	if err == nil {
+		t.Fatal("expected an error combining file uploads with Automatic Persisted Queries, got nil")
+	}
+}
+
+func TestBatchingClient_RejectsPersistedQueries(t *testing.T) {
+	c := NewBatchingClient(NewClient(http.DefaultClient, "http://example.invalid"), 0, 1)
+	c.WithPersistedQueries(nil)
+
+	err := c.Post(context.Background(), "query", &struct{}{}, nil)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestBatchingClient_RejectsMiddlewares(t *testing.T) {
+	inner := NewClient(http.DefaultClient, "http://example.invalid")
+	inner.Use(func(next RoundTripper) RoundTripper { return next })
+	c := NewBatchingClient(inner, 0, 1)
+
+	err := c.Post(context.Background(), "query", &struct{}{}, nil)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"golang.org/x/xerrors"
+)
+
+// Response is a decoded GraphQL-layer response, as seen by the middleware
+// chain before Post unmarshals Data into the caller's struct.
+type Response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors gqlerror.List   `json:"errors"`
+
+	// networkError carries the HTTP status when the transport got a non-2xx
+	// response that nonetheless decoded a well-formed GraphQL errors body -
+	// some servers do both at once. finishResponse surfaces it on
+	// ErrorResponse.NetworkError alongside GqlErrors, rather than dropping
+	// the HTTP status on the floor just because the body also parsed.
+	networkError *HTTPError
+}
+
+// RoundTripper performs one GraphQL operation and returns its decoded
+// response. Unlike http.RoundTripper it operates at the GraphQL layer, so
+// middlewares can see GqlErrorList-shaped errors that the raw HTTP
+// transport knows nothing about.
+type RoundTripper interface {
+	RoundTrip(ctx context.Context, req *Request) (*Response, error)
+}
+
+// RoundTripperFunc adapts a function to a RoundTripper.
+type RoundTripperFunc func(ctx context.Context, req *Request) (*Response, error)
+
+// RoundTrip calls f(ctx, req).
+func (f RoundTripperFunc) RoundTrip(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
+
+// Middleware wraps a RoundTripper with additional behavior such as retries,
+// logging or tracing.
+type Middleware func(next RoundTripper) RoundTripper
+
+// Use installs middlewares around the client's GraphQL transport, in the
+// order given: the first middleware sees the request first and the
+// response last.
+func (c *Client) Use(middlewares ...Middleware) *Client {
+	c.Middlewares = append(c.Middlewares, middlewares...)
+	return c
+}
+
+// chain wraps terminal with the client's installed middlewares. terminal is
+// whichever RoundTripper actually performs the HTTP call - the plain JSON
+// one, or the multipart one uploads need - so that middlewares (retry,
+// logging, tracing, rate limiting...) apply uniformly no matter which
+// terminal Post picked for this call.
+func (c *Client) chain(terminal RoundTripper) RoundTripper {
+	rt := terminal
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		rt = c.Middlewares[i](rt)
+	}
+
+	return rt
+}
+
+// terminalRoundTripper returns the RoundTripper that actually performs the
+// HTTP call for this Post: JSON-encoded when there are no uploads,
+// multipart/form-data (per the GraphQL multipart request spec) when there
+// are.
+func (c *Client) terminalRoundTripper(uploads []fileUpload, httpRequestOptions []HTTPRequestOption) RoundTripper {
+	if len(uploads) > 0 {
+		return RoundTripperFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			return c.multipartRoundTrip(ctx, req, uploads, httpRequestOptions)
+		})
+	}
+
+	return RoundTripperFunc(func(ctx context.Context, req *Request) (*Response, error) {
+		return c.jsonRoundTrip(ctx, req, httpRequestOptions)
+	})
+}
+
+// jsonRoundTrip is the plain terminal RoundTripper: it encodes req as JSON,
+// performs the HTTP call and decodes the body into a Response.
+func (c *Client) jsonRoundTrip(ctx context.Context, req *Request, httpRequestOptions []HTTPRequestOption) (*Response, error) {
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, xerrors.Errorf("encode: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, xerrors.Errorf("create request struct failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	httpReq.Header.Set("Accept", "application/json; charset=utf-8")
+	c.applyHTTPRequestOptions(httpReq, httpRequestOptions)
+
+	body, httpCode, err := c.doHTTP(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResponse(body, httpCode)
+}
+
+// decodeResponse decodes a raw HTTP response body into a Response, the
+// shape every terminal RoundTripper (and BatchingClient, which bypasses the
+// RoundTripper chain entirely) returns to its caller.
+func decodeResponse(body []byte, httpCode int) (*Response, error) {
+	isKOCode := httpCode < 200 || 299 < httpCode
+
+	gqlResp := &Response{}
+	if err := json.Unmarshal(body, gqlResp); err != nil {
+		if isKOCode {
+			return nil, &HTTPError{Code: httpCode, Message: fmt.Sprintf("Response body %s", string(body))}
+		}
+		return nil, xerrors.Errorf("failed to decode data %s: %w", string(body), err)
+	}
+
+	if isKOCode {
+		if len(gqlResp.Errors) == 0 {
+			return nil, &HTTPError{Code: httpCode, Message: fmt.Sprintf("Response body %s", string(body))}
+		}
+		gqlResp.networkError = &HTTPError{Code: httpCode, Message: fmt.Sprintf("Response body %s", string(body))}
+	}
+
+	return gqlResp, nil
+}
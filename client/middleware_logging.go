@@ -0,0 +1,29 @@
+package client
+
+import "context"
+
+// Logger is the minimal logging interface used by LoggingMiddleware;
+// *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// LoggingMiddleware logs each request's operation name (if any) and
+// whether it failed, at the GraphQL layer.
+func LoggingMiddleware(logger Logger) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			resp, err := next.RoundTrip(ctx, req)
+			switch {
+			case err != nil:
+				logger.Printf("graphql request %q failed: %s", req.OperationName, err)
+			case len(resp.Errors) > 0:
+				logger.Printf("graphql request %q returned %d error(s): %s", req.OperationName, len(resp.Errors), resp.Errors)
+			default:
+				logger.Printf("graphql request %q succeeded", req.OperationName)
+			}
+
+			return resp, err
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+	"golang.org/x/xerrors"
+)
+
+// RateLimitMiddleware blocks each request until limiter permits it, turning
+// limiter into a client-side token-bucket rate limiter for the GraphQL
+// transport.
+func RateLimitMiddleware(limiter *rate.Limiter) Middleware {
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, xerrors.Errorf("rate limit wait: %w", err)
+			}
+
+			return next.RoundTrip(ctx, req)
+		})
+	}
+}
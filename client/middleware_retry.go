@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// RetryConfig configures RetryMiddleware's exponential backoff schedule.
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first
+	// one. It defaults to 3.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles after
+	// each subsequent attempt. It defaults to 200ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay. It defaults to 5s.
+	MaxDelay time.Duration
+}
+
+func (cfg RetryConfig) withDefaults() RetryConfig {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 200 * time.Millisecond
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 5 * time.Second
+	}
+
+	return cfg
+}
+
+// RetryMiddleware retries a request with exponential backoff when the
+// transport reports a 5xx/network error, or when the decoded response
+// carries a GraphQL error with extensions.code == "RATE_LIMITED".
+func RetryMiddleware(cfg RetryConfig) Middleware {
+	cfg = cfg.withDefaults()
+
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			delay := cfg.BaseDelay
+
+			var resp *Response
+			var err error
+			for attempt := 1; attempt <= cfg.MaxAttempts; attempt++ {
+				resp, err = next.RoundTrip(ctx, req)
+				if !shouldRetry(resp, err) || attempt == cfg.MaxAttempts {
+					return resp, err
+				}
+
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+
+				delay *= 2
+				if delay > cfg.MaxDelay {
+					delay = cfg.MaxDelay
+				}
+			}
+
+			return resp, err
+		})
+	}
+}
+
+func shouldRetry(resp *Response, err error) bool {
+	if err != nil {
+		httpErr, ok := err.(*HTTPError)
+		return !ok || httpErr.Code >= 500
+	}
+
+	if resp.networkError != nil && resp.networkError.Code >= 500 {
+		return true
+	}
+
+	for _, e := range resp.Errors {
+		if code, ok := e.Extensions["code"]; ok && code == "RATE_LIMITED" {
+			return true
+		}
+	}
+
+	return false
+}
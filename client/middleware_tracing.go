@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is used as the OpenTelemetry instrumentation library name for
+// spans created by TracingMiddleware.
+const tracerName = "github.com/kodesmil/gqlgenc/client"
+
+// TracingMiddleware starts an OpenTelemetry span for each request, named
+// after its GraphQL operation name, with the query and variables recorded
+// as attributes and any error reflected in the span status.
+func TracingMiddleware(tracerProvider trace.TracerProvider) Middleware {
+	tracer := tracerProvider.Tracer(tracerName)
+
+	return func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, req *Request) (*Response, error) {
+			spanName := req.OperationName
+			if spanName == "" {
+				spanName = "graphql.request"
+			}
+
+			attrs := []attribute.KeyValue{
+				attribute.String("graphql.operation_name", req.OperationName),
+				attribute.String("graphql.query", req.Query),
+			}
+			if len(req.Variables) > 0 {
+				if vars, err := json.Marshal(req.Variables); err == nil {
+					attrs = append(attrs, attribute.String("graphql.variables", string(vars)))
+				}
+			}
+
+			ctx, span := tracer.Start(ctx, spanName, trace.WithAttributes(attrs...))
+			defer span.End()
+
+			resp, err := next.RoundTrip(ctx, req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+
+			if len(resp.Errors) > 0 {
+				span.RecordError(resp.Errors)
+				span.SetStatus(codes.Error, resp.Errors.Error())
+			}
+
+			return resp, err
+		})
+	}
+}
+
+// DefaultTracingMiddleware uses the global OpenTelemetry TracerProvider.
+func DefaultTracingMiddleware() Middleware {
+	return TracingMiddleware(otel.GetTracerProvider())
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestPost_PersistedQuery_NewHash_SkipsHashOnlyProbe(t *testing.T) {
+	var requests []Request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), srv.URL).WithPersistedQueries(nil)
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	if err := c.Post(context.Background(), "query { ok }", &resp, nil); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("server saw %d requests, want 1 - a hash never registered before should skip the doomed hash-only probe", len(requests))
+	}
+	if requests[0].Query == "" {
+		t.Fatal("the one request sent has no query text, but the server has never seen this hash")
+	}
+}
+
+func TestPost_PersistedQuery_KnownHash_TriesHashOnlyFirst(t *testing.T) {
+	var requests []Request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer srv.Close()
+
+	cache := NewInMemoryPersistedQueryCache()
+	const query = "query { ok }"
+	cache.Set(hashQuery(query), query)
+
+	c := NewClient(srv.Client(), srv.URL).WithPersistedQueries(cache)
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	if err := c.Post(context.Background(), query, &resp, nil); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("server saw %d requests, want 1", len(requests))
+	}
+	if requests[0].Query != "" {
+		t.Fatalf("request carried the full query text %q, want a hash-only probe since the cache already had this hash", requests[0].Query)
+	}
+}
+
+func TestIsPersistedQueryNotFound_MatchesUpperSnakeCaseCode(t *testing.T) {
+	// Message deliberately doesn't say "PersistedQueryNotFound", so this
+	// only passes if the extensions.code branch itself matches.
+	errs := gqlerror.List{{
+		Message:    "persisted query not found",
+		Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_FOUND"},
+	}}
+	errResp := &ErrorResponse{GqlErrors: &errs}
+
+	if !isPersistedQueryNotFound(errResp) {
+		t.Fatal("expected the upper-snake-case extensions.code used by real APQ servers to be recognised")
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+
+	"github.com/kodesmil/gqlgenc/querybuilder"
+	"golang.org/x/xerrors"
+)
+
+// Query runs an ad-hoc typed query built from q, a pointer to a struct whose
+// fields carry `graphql:"..."` tags describing the selection set (see
+// package querybuilder). The response is decoded directly into q.
+func (c *Client) Query(ctx context.Context, q interface{}, vars map[string]interface{}, httpRequestOptions ...HTTPRequestOption) error {
+	query, err := querybuilder.Construct("query", q, vars)
+	if err != nil {
+		return xerrors.Errorf("construct query: %w", err)
+	}
+
+	return c.Post(ctx, query, q, vars, httpRequestOptions...)
+}
+
+// Mutate runs an ad-hoc typed mutation built from m, the same way Query
+// does. input is passed to the server as the `$input` variable, the
+// conventional name for a mutation's primary argument; additional variables
+// referenced by m's tags can be supplied via vars.
+func (c *Client) Mutate(ctx context.Context, m interface{}, input interface{}, vars map[string]interface{}, httpRequestOptions ...HTTPRequestOption) error {
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	vars["input"] = input
+
+	query, err := querybuilder.Construct("mutation", m, vars)
+	if err != nil {
+		return xerrors.Errorf("construct mutation: %w", err)
+	}
+
+	return c.Post(ctx, query, m, vars, httpRequestOptions...)
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func newSubscriptionTestServer(t *testing.T, onConnected func(conn *websocket.Conn)) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{Subprotocols: []string{graphqlTransportWSProtocol}}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		var initMsg wsMessage
+		if err := conn.ReadJSON(&initMsg); err != nil {
+			return
+		}
+		if err := conn.WriteJSON(wsMessage{Type: msgConnectionAck}); err != nil {
+			return
+		}
+
+		onConnected(conn)
+	}))
+}
+
+func wsURL(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	return "ws" + srv.URL[len("http"):]
+}
+
+func TestSubscriptionClient_connect_DoesNotMutateSharedDialer(t *testing.T) {
+	srv := newSubscriptionTestServer(t, func(conn *websocket.Conn) {
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	defer srv.Close()
+
+	sharedDialer := &websocket.Dialer{}
+	c := NewSubscriptionClient(wsURL(t, srv), nil)
+	c.WSDialer = sharedDialer
+
+	if _, err := c.connect(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Close()
+
+	if len(sharedDialer.Subprotocols) != 0 {
+		t.Fatalf("connect mutated the shared dialer: Subprotocols = %v", sharedDialer.Subprotocols)
+	}
+}
+
+func TestSubscriptionClient_readLoop_ClosesConnOnDisconnect(t *testing.T) {
+	srv := newSubscriptionTestServer(t, func(conn *websocket.Conn) {
+		conn.Close() // disconnect immediately after the handshake
+	})
+	defer srv.Close()
+
+	c := NewSubscriptionClient(wsURL(t, srv), nil)
+
+	conn, err := c.connect(context.Background())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		c.mu.Lock()
+		observed := c.conn == nil
+		c.mu.Unlock()
+		if observed {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for readLoop to observe the disconnect")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("x")); err == nil {
+		t.Fatal("expected the connection to have been closed by readLoop, but a write on it succeeded")
+	}
+}
+
+// TestSubscriptionClient_UnsubscribeDuringDispatch_DoesNotRace reproduces a
+// consumer that reads one event then calls unsubscribe() while a second
+// "next" frame is still being dispatched. Run with -race: before the fix,
+// dispatch's send on the subscriber channel could race unsubscribe's close
+// of that same channel and panic with "send on closed channel".
+func TestSubscriptionClient_UnsubscribeDuringDispatch_DoesNotRace(t *testing.T) {
+	srv := newSubscriptionTestServer(t, func(conn *websocket.Conn) {
+		defer conn.Close()
+		for {
+			var msg wsMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Type != msgSubscribe {
+				continue
+			}
+			// Two frames back to back, with no delay: the second dispatch
+			// can still be in flight when the consumer unsubscribes right
+			// after reading the first.
+			for i := 0; i < 2; i++ {
+				if err := conn.WriteJSON(wsMessage{ID: msg.ID, Type: msgNext, Payload: json.RawMessage("null")}); err != nil {
+					return
+				}
+			}
+		}
+	})
+	defer srv.Close()
+
+	c := NewSubscriptionClient(wsURL(t, srv), nil)
+	defer c.Close()
+
+	for i := 0; i < 50; i++ {
+		ch, unsubscribe, err := c.Subscribe(context.Background(), "subscription{x}", nil)
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+
+		<-ch
+		if err := unsubscribe(); err != nil {
+			t.Fatalf("unsubscribe: %v", err)
+		}
+	}
+}
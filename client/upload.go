@@ -0,0 +1,142 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+
+	"golang.org/x/xerrors"
+)
+
+// Upload represents a file variable to send as part of a multipart GraphQL
+// request, following the GraphQL multipart request spec
+// (https://github.com/jaydenseric/graphql-multipart-request-spec). Any
+// variable value of this type makes Post switch from a plain JSON body to
+// multipart/form-data.
+//
+// Callers pass this in by hand for now: generated mutations don't map the
+// schema's Upload scalar to it automatically, since that mapping is a
+// clientgen change and this tree has no clientgen package for it to land
+// in.
+type Upload struct {
+	File        io.Reader
+	Filename    string
+	ContentType string
+}
+
+// fileUpload pairs an Upload with the dotted path (e.g. "variables.file" or
+// "variables.files.0") it was found at within the request variables.
+type fileUpload struct {
+	path string
+	file Upload
+}
+
+// collectUploads walks vars looking for Upload values. It returns a copy of
+// vars with every Upload replaced by nil, so it marshals to null in the
+// "operations" part of the request, along with the list of uploads found
+// and the variable path each was found at.
+func collectUploads(vars map[string]interface{}) (map[string]interface{}, []fileUpload) {
+	var uploads []fileUpload
+	cleaned, _ := stripUploads("variables", vars, &uploads).(map[string]interface{})
+
+	return cleaned, uploads
+}
+
+func stripUploads(path string, v interface{}, uploads *[]fileUpload) interface{} {
+	switch val := v.(type) {
+	case Upload:
+		*uploads = append(*uploads, fileUpload{path: path, file: val})
+		return nil
+	case *Upload:
+		if val == nil {
+			return nil
+		}
+		*uploads = append(*uploads, fileUpload{path: path, file: *val})
+		return nil
+	case map[string]interface{}:
+		cleaned := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			cleaned[k] = stripUploads(path+"."+k, v, uploads)
+		}
+		return cleaned
+	case []interface{}:
+		cleaned := make([]interface{}, len(val))
+		for i, v := range val {
+			cleaned[i] = stripUploads(path+"."+strconv.Itoa(i), v, uploads)
+		}
+		return cleaned
+	default:
+		return v
+	}
+}
+
+// multipartRoundTrip is the multipart terminal RoundTripper: it encodes req
+// and uploads per the GraphQL multipart request spec, performs the HTTP
+// call and decodes the body into a Response, the same as jsonRoundTrip.
+func (c *Client) multipartRoundTrip(ctx context.Context, req *Request, uploads []fileUpload, httpRequestOptions []HTTPRequestOption) (*Response, error) {
+	operations, err := json.Marshal(req)
+	if err != nil {
+		return nil, xerrors.Errorf("encode operations: %w", err)
+	}
+
+	fileMap := make(map[string][]string, len(uploads))
+	for i, u := range uploads {
+		fileMap[strconv.Itoa(i)] = []string{u.path}
+	}
+	mapField, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, xerrors.Errorf("encode map: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	if err := w.WriteField("operations", string(operations)); err != nil {
+		return nil, xerrors.Errorf("write operations part: %w", err)
+	}
+	if err := w.WriteField("map", string(mapField)); err != nil {
+		return nil, xerrors.Errorf("write map part: %w", err)
+	}
+
+	for i, u := range uploads {
+		contentType := u.file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, strconv.Itoa(i), u.file.Filename))
+		header.Set("Content-Type", contentType)
+
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, xerrors.Errorf("create file part %d: %w", i, err)
+		}
+		if _, err := io.Copy(part, u.file.File); err != nil {
+			return nil, xerrors.Errorf("write file part %d: %w", i, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, xerrors.Errorf("close multipart writer: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, &buf)
+	if err != nil {
+		return nil, xerrors.Errorf("create request struct failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", w.FormDataContentType())
+	c.applyHTTPRequestOptions(httpReq, httpRequestOptions)
+
+	body, httpCode, err := c.doHTTP(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResponse(body, httpCode)
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPost_Upload_KeepsVariablePlaceholderInOperations(t *testing.T) {
+	var capturedOperations []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+			t.Fatalf("expected a multipart request, got %q (%v)", r.Header.Get("Content-Type"), err)
+		}
+
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("read part: %v", err)
+			}
+			if part.FormName() == "operations" {
+				capturedOperations, err = io.ReadAll(part)
+				if err != nil {
+					t.Fatalf("read operations part: %v", err)
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), srv.URL)
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	err := c.Post(context.Background(), "mutation", &resp, map[string]interface{}{
+		"file": Upload{File: strings.NewReader("contents"), Filename: "a.txt"},
+	})
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	var operations struct {
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(capturedOperations, &operations); err != nil {
+		t.Fatalf("decode operations part %s: %v", capturedOperations, err)
+	}
+
+	val, ok := operations.Variables["file"]
+	if !ok {
+		t.Fatalf(`operations.variables is missing the "file" key entirely: %s`, capturedOperations)
+	}
+	if val != nil {
+		t.Fatalf("expected the file variable to be null in operations, got %v", val)
+	}
+}
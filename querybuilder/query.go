@@ -0,0 +1,145 @@
+// Package querybuilder builds GraphQL query and mutation documents from Go
+// structs, in the style of shurcooL/graphql: a pointer to a struct whose
+// fields carry `graphql:"..."` tags is reflected into a selection set, so
+// callers can run ad-hoc typed queries without running clientgen first.
+package querybuilder
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// Construct builds a GraphQL document for the given operation ("query" or
+// "mutation") by reflecting over v, which must be a pointer to a struct.
+// variables, if non-empty, are declared in the operation signature with
+// GraphQL types inferred from their Go values.
+func Construct(operation string, v interface{}, variables map[string]interface{}) (string, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return "", xerrors.Errorf("v must be a pointer to a struct, got %T", v)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(operation)
+
+	if len(variables) > 0 {
+		decl, err := variableDeclarations(variables)
+		if err != nil {
+			return "", xerrors.Errorf("build variable declarations: %w", err)
+		}
+		buf.WriteString(decl)
+	}
+
+	buf.WriteString(" {")
+	if err := writeSelectionSet(&buf, value.Elem().Type()); err != nil {
+		return "", xerrors.Errorf("build selection set: %w", err)
+	}
+	buf.WriteString("\n}")
+
+	return buf.String(), nil
+}
+
+func variableDeclarations(variables map[string]interface{}) (string, error) {
+	var decls []string
+	for name, v := range variables {
+		typ, err := graphqlType(reflect.ValueOf(v))
+		if err != nil {
+			return "", xerrors.Errorf("variable %q: %w", name, err)
+		}
+		decls = append(decls, "$"+name+": "+typ)
+	}
+
+	return "(" + strings.Join(decls, ", ") + ")", nil
+}
+
+// graphqlType infers a GraphQL input type from a Go value. It only covers
+// the scalar and list shapes that show up in query/mutation arguments;
+// structured input types must be passed through a scalar-shaped wrapper
+// (e.g. a marshalled ID or JSON string) rather than a nested struct.
+func graphqlType(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", xerrors.Errorf("cannot infer GraphQL type from a nil variable; wrap it in a typed *T pointer")
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", xerrors.Errorf("cannot infer GraphQL type from a nil pointer")
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return "Boolean!", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Int!", nil
+	case reflect.Float32, reflect.Float64:
+		return "Float!", nil
+	case reflect.String:
+		return "String!", nil
+	case reflect.Slice, reflect.Array:
+		elemType, err := graphqlType(reflect.New(v.Type().Elem()).Elem())
+		if err != nil {
+			return "", err
+		}
+		return "[" + elemType + "]!", nil
+	default:
+		return "", xerrors.Errorf("cannot infer GraphQL type for %s", v.Type())
+	}
+}
+
+// writeSelectionSet writes the fields of t as a GraphQL selection set.
+func writeSelectionSet(buf *bytes.Buffer, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("graphql")
+		if ok && tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && strings.HasPrefix(tag, "... on ") {
+			buf.WriteString("\n... on " + strings.TrimPrefix(tag, "... on ") + " {")
+			if err := writeSelectionSet(buf, underlyingStructType(field.Type)); err != nil {
+				return err
+			}
+			buf.WriteString("\n}")
+			continue
+		}
+
+		selector := tag
+		if selector == "" {
+			selector = field.Name
+		}
+		buf.WriteString("\n" + selector)
+
+		fieldType := underlyingStructType(field.Type)
+		if fieldType == nil {
+			continue
+		}
+		buf.WriteString(" {")
+		if err := writeSelectionSet(buf, fieldType); err != nil {
+			return err
+		}
+		buf.WriteString("\n}")
+	}
+
+	return nil
+}
+
+// underlyingStructType unwraps pointers and slices down to the struct type
+// being selected into, or returns nil for scalar/leaf fields.
+func underlyingStructType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return t
+	}
+
+	return nil
+}
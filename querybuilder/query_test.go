@@ -0,0 +1,33 @@
+package querybuilder
+
+import "testing"
+
+func TestConstruct_NilVariable_ReturnsErrorNotPanic(t *testing.T) {
+	var q struct {
+		User struct {
+			Name string `graphql:"name"`
+		} `graphql:"user(id: $id)"`
+	}
+
+	if _, err := Construct("query", &q, map[string]interface{}{"id": nil}); err == nil {
+		t.Fatal("expected an error for a nil variable, got nil")
+	}
+}
+
+func TestConstruct_BuildsSelectionSet(t *testing.T) {
+	var q struct {
+		Viewer struct {
+			Login string `graphql:"login"`
+		} `graphql:"viewer"`
+	}
+
+	query, err := Construct("query", &q, nil)
+	if err != nil {
+		t.Fatalf("Construct: %v", err)
+	}
+
+	const want = "query {\nviewer {\nlogin\n}\n}"
+	if query != want {
+		t.Fatalf("query = %q, want %q", query, want)
+	}
+}